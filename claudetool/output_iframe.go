@@ -34,20 +34,36 @@ Good uses:
 - SVG graphics
 
 The HTML should be self-contained. You can include inline <script> and <style> tags.
-External resources can be loaded via CDN (e.g., https://cdn.jsdelivr.net/).`
+External resources can be loaded via CDN (e.g., https://cdn.jsdelivr.net/).
+
+Instead of html, you can pass template + data: a small template plus a large JSON
+dataset is cheaper on tokens than concatenating them yourself, and keeps your data
+out of the markup. See the template/data/engine fields below.`
 
 	outputIframeInputSchema = `
 {
   "type": "object",
-  "required": ["html"],
   "properties": {
     "html": {
       "type": "string",
-      "description": "The HTML content to display. Should be a complete HTML document or fragment."
+      "description": "The HTML content to display. Should be a complete HTML document or fragment. Mutually exclusive with template."
     },
     "title": {
-      "type": "string", 
+      "type": "string",
       "description": "Optional title describing the visualization"
+    },
+    "template": {
+      "type": "string",
+      "description": "A template string rendered server-side against data to produce the HTML. Mutually exclusive with html. Helpers available with engine \"go\": json, humanize_bytes, humanize_time, default, slice."
+    },
+    "data": {
+      "type": "object",
+      "description": "JSON data bound to template."
+    },
+    "engine": {
+      "type": "string",
+      "enum": ["go", "handlebars"],
+      "description": "Template language used to render template. Defaults to \"go\" (Go's html/template, auto-escaped)."
     }
   }
 }
@@ -59,25 +75,47 @@ type OutputIframeDisplay struct {
 	Type  string `json:"type"`
 	HTML  string `json:"html"`
 	Title string `json:"title,omitempty"`
+	// CSP is the Content-Security-Policy the UI (or the local iframe
+	// server) should apply alongside HTML, derived from what the
+	// sanitized payload actually references. See sanitize.go.
+	CSP string `json:"csp,omitempty"`
 }
 
 func outputIframeRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
 	var input struct {
-		HTML  string `json:"html"`
-		Title string `json:"title"`
+		HTML     string          `json:"html"`
+		Title    string          `json:"title"`
+		Template string          `json:"template"`
+		Data     json.RawMessage `json:"data"`
+		Engine   string          `json:"engine"`
 	}
 	if err := json.Unmarshal(m, &input); err != nil {
 		return llm.ErrorToolOut(err)
 	}
 
-	if input.HTML == "" {
-		return llm.ErrorfToolOut("html content is required")
+	htmlSrc := input.HTML
+	if input.Template != "" {
+		rendered, err := renderOutputTemplate(input.Template, input.Data, input.Engine)
+		if err != nil {
+			return llm.ErrorToolOut(err)
+		}
+		htmlSrc = rendered
+	}
+
+	if htmlSrc == "" {
+		return llm.ErrorfToolOut("html or template content is required")
+	}
+
+	sanitized, err := DefaultSanitizePolicy.Sanitize(htmlSrc)
+	if err != nil {
+		return llm.ErrorToolOut(err)
 	}
 
 	display := OutputIframeDisplay{
 		Type:  "output_iframe",
-		HTML:  input.HTML,
+		HTML:  sanitized.HTML,
 		Title: input.Title,
+		CSP:   sanitized.csp(),
 	}
 
 	return llm.ToolOut{
@@ -0,0 +1,271 @@
+package claudetool
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"shelley.exe.dev/llm"
+)
+
+// IframeServerOptions configures the optional local HTTP server that makes
+// output_iframe usable in headless or plain-terminal sessions, where there's
+// no UI able to render an OutputIframeDisplay directly.
+type IframeServerOptions struct {
+	// Enable turns on the local server. When false, NewOutputIframeTool
+	// behaves exactly like the package-level OutputIframeTool.
+	Enable bool
+
+	// OpenBrowser launches the user's default browser on each new payload,
+	// instead of only returning its URL to the model.
+	OpenBrowser bool
+
+	// BindAddr is the address the server listens on, e.g. "127.0.0.1:0".
+	// An empty value defaults to "127.0.0.1:0", which picks a random free port.
+	BindAddr string
+
+	// MaxHistory bounds how many past payloads are kept in memory so users
+	// can revisit them from the index page. Defaults to 20.
+	MaxHistory int
+
+	// TemplateFuncs, if set, extends the funcs available to the host page
+	// template.
+	TemplateFuncs template.FuncMap
+}
+
+// IframeServer hosts output_iframe payloads at http://127.0.0.1:<port>/i/<uuid>
+// so they can be viewed in a real browser instead of a UI-rendered widget.
+type IframeServer struct {
+	opts     IframeServerOptions
+	listener net.Listener
+	http     *http.Server
+	baseURL  string
+
+	mu      sync.Mutex
+	history []cachedIframe
+}
+
+// cachedIframe is one payload kept around for the index page.
+type cachedIframe struct {
+	ID        string
+	Title     string
+	HTML      string
+	CSP       string
+	CreatedAt time.Time
+}
+
+const defaultMaxHistory = 20
+
+// NewOutputIframeTool returns an output_iframe tool. If opts.Enable is false
+// it's equivalent to the package-level OutputIframeTool: payloads are only
+// returned as an OutputIframeDisplay for a UI to render. If opts.Enable is
+// true, a local HTTP server is started and the tool additionally serves each
+// payload at a unique URL, returning it to the model (and optionally opening
+// a browser).
+//
+// The caller is responsible for calling Close on the returned *IframeServer
+// when the conversation ends; Close is a no-op if the server was never
+// started.
+func NewOutputIframeTool(opts IframeServerOptions) (*llm.Tool, *IframeServer, error) {
+	srv := &IframeServer{opts: opts}
+	if !opts.Enable {
+		return OutputIframeTool, srv, nil
+	}
+
+	if opts.MaxHistory <= 0 {
+		srv.opts.MaxHistory = defaultMaxHistory
+	}
+	bindAddr := opts.BindAddr
+	if bindAddr == "" {
+		bindAddr = "127.0.0.1:0"
+	}
+
+	ln, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("output_iframe: failed to bind local server: %w", err)
+	}
+	srv.listener = ln
+	srv.baseURL = "http://" + ln.Addr().String()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", srv.handleIndex)
+	mux.HandleFunc("/i/", srv.handlePayload)
+	srv.http = &http.Server{Handler: mux}
+	go func() {
+		if err := srv.http.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("output_iframe: server stopped: %v", err)
+		}
+	}()
+
+	tool := &llm.Tool{
+		Name:        outputIframeName,
+		Description: outputIframeDescription,
+		InputSchema: llm.MustSchema(outputIframeInputSchema),
+		Run:         srv.run,
+	}
+	return tool, srv, nil
+}
+
+// Close shuts the local server down, if one was started.
+func (s *IframeServer) Close() error {
+	if s == nil || s.http == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.http.Shutdown(ctx)
+}
+
+func (s *IframeServer) run(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	out := outputIframeRun(ctx, m)
+	if out.Error != nil {
+		return out
+	}
+	display, ok := out.Display.(OutputIframeDisplay)
+	if !ok {
+		return out
+	}
+
+	id := s.remember(display)
+	url := s.baseURL + "/i/" + id
+
+	if s.opts.OpenBrowser {
+		if err := openBrowser(url); err != nil {
+			log.Printf("output_iframe: failed to open browser: %v", err)
+		}
+	}
+
+	out.LLMContent = llm.TextContent(fmt.Sprintf("displayed at %s", url))
+	return out
+}
+
+// remember stores display in the bounded history and returns its id.
+func (s *IframeServer) remember(display OutputIframeDisplay) string {
+	id := newIframeID()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, cachedIframe{
+		ID:        id,
+		Title:     display.Title,
+		HTML:      display.HTML,
+		CSP:       display.CSP,
+		CreatedAt: time.Now(),
+	})
+	if over := len(s.history) - s.opts.MaxHistory; over > 0 {
+		s.history = s.history[over:]
+	}
+	return id
+}
+
+func (s *IframeServer) find(id string) (cachedIframe, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.history {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return cachedIframe{}, false
+}
+
+func (s *IframeServer) handlePayload(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[len("/i/"):]
+	payload, ok := s.find(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	csp := payload.CSP
+	if csp == "" {
+		csp = defaultHostCSP
+	}
+	w.Header().Set("Content-Security-Policy", csp)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := hostPageTemplate(s.opts.TemplateFuncs).Execute(w, payload); err != nil {
+		log.Printf("output_iframe: failed to render host page: %v", err)
+	}
+}
+
+func (s *IframeServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	items := make([]cachedIframe, len(s.history))
+	copy(items, s.history)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := indexPageTemplate.Execute(w, items); err != nil {
+		log.Printf("output_iframe: failed to render index page: %v", err)
+	}
+}
+
+// defaultHostCSP is sent with the host page only for a payload that carries
+// no CSP of its own; otherwise the payload's policy, derived in sanitize.go,
+// is sent instead.
+const defaultHostCSP = "default-src 'none'; frame-src 'self'; style-src 'unsafe-inline'"
+
+func hostPageTemplate(extra template.FuncMap) *template.Template {
+	t := template.New("host")
+	if extra != nil {
+		t = t.Funcs(extra)
+	}
+	return template.Must(t.Parse(hostPageHTML))
+}
+
+const hostPageHTML = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{if .Title}}{{.Title}}{{else}}output_iframe{{end}}</title></head>
+<body style="margin:0">
+<iframe sandbox="allow-scripts" style="border:0;width:100vw;height:100vh" srcdoc="{{.HTML}}"></iframe>
+</body>
+</html>`
+
+var indexPageTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>output_iframe history</title></head>
+<body>
+<h1>output_iframe history</h1>
+<ul>
+{{range .}}<li><a href="/i/{{.ID}}">{{if .Title}}{{.Title}}{{else}}{{.ID}}{{end}}</a> &mdash; {{.CreatedAt.Format "15:04:05"}} &mdash; {{len .HTML}} bytes</li>
+{{else}}<li>(empty)</li>
+{{end}}
+</ul>
+</body>
+</html>`))
+
+func newIframeID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// openBrowser launches the platform's default browser on url.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,83 @@
+package claudetool
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewOutputIframeToolDisabled(t *testing.T) {
+	tool, srv, err := NewOutputIframeTool(IframeServerOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tool != OutputIframeTool {
+		t.Error("expected disabled tool to be the package-level OutputIframeTool")
+	}
+	if err := srv.Close(); err != nil {
+		t.Errorf("Close on unstarted server should be a no-op: %v", err)
+	}
+}
+
+func TestIframeServerRememberBoundsHistory(t *testing.T) {
+	srv := &IframeServer{opts: IframeServerOptions{MaxHistory: 2}}
+
+	ids := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		id := srv.remember(OutputIframeDisplay{Type: "output_iframe", HTML: "<p>x</p>"})
+		ids = append(ids, id)
+	}
+
+	if len(srv.history) != 2 {
+		t.Fatalf("expected history capped at 2, got %d", len(srv.history))
+	}
+	if _, ok := srv.find(ids[0]); ok {
+		t.Error("expected oldest entry to be evicted")
+	}
+	if _, ok := srv.find(ids[2]); !ok {
+		t.Error("expected newest entry to still be present")
+	}
+}
+
+func TestNewOutputIframeToolEnabledServesPayload(t *testing.T) {
+	tool, srv, err := NewOutputIframeTool(IframeServerOptions{Enable: true, BindAddr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer srv.Close()
+
+	input, _ := json.Marshal(map[string]any{"html": "<h1>Hi</h1>", "title": "Test"})
+	out := tool.Run(context.Background(), input)
+	if out.Error != nil {
+		t.Fatalf("unexpected error: %v", out.Error)
+	}
+	if len(out.LLMContent) != 1 || !strings.Contains(out.LLMContent[0].Text, srv.baseURL) {
+		t.Errorf("expected LLMContent to mention the server URL, got %v", out.LLMContent)
+	}
+
+	if len(srv.history) != 1 {
+		t.Fatalf("expected one payload recorded, got %d", len(srv.history))
+	}
+	id := srv.history[0].ID
+
+	resp, err := http.Get(srv.baseURL + "/i/" + id)
+	if err != nil {
+		t.Fatalf("failed to fetch payload: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+
+	idxResp, err := http.Get(srv.baseURL + "/")
+	if err != nil {
+		t.Fatalf("failed to fetch index: %v", err)
+	}
+	defer idxResp.Body.Close()
+	if idxResp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 from index, got %d", idxResp.StatusCode)
+	}
+}
@@ -0,0 +1,173 @@
+package claudetool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/aymerick/raymond"
+)
+
+// templateEngine selects which template language renderOutputTemplate uses.
+type templateEngine string
+
+const (
+	engineGo         templateEngine = "go"
+	engineHandlebars templateEngine = "handlebars"
+)
+
+// templateFuncs are the helpers available to an output_iframe "go" template,
+// in addition to html/template's builtins.
+var templateFuncs = template.FuncMap{
+	"json":           templateJSON,
+	"humanize_bytes": humanizeBytes,
+	"humanize_time":  humanizeTime,
+	"default":        templateDefault,
+	"slice":          templateSlice,
+}
+
+// renderOutputTemplate renders src against rawData (a JSON object, or nil)
+// using the named engine, defaulting to "go" when engine is empty.
+func renderOutputTemplate(src string, rawData json.RawMessage, engine string) (string, error) {
+	var data any
+	if len(rawData) > 0 {
+		if err := json.Unmarshal(rawData, &data); err != nil {
+			return "", fmt.Errorf("output_iframe: failed to parse data: %w", err)
+		}
+	}
+
+	switch templateEngine(engine) {
+	case "", engineGo:
+		return renderGoTemplate(src, data)
+	case engineHandlebars:
+		return renderHandlebarsTemplate(src, data)
+	default:
+		return "", fmt.Errorf("output_iframe: unknown template engine %q, want \"go\" or \"handlebars\"", engine)
+	}
+}
+
+func renderGoTemplate(src string, data any) (string, error) {
+	tmpl, err := template.New("output_iframe").Funcs(templateFuncs).Parse(src)
+	if err != nil {
+		return "", formatTemplateError(err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", formatTemplateError(err)
+	}
+	return buf.String(), nil
+}
+
+func renderHandlebarsTemplate(src string, data any) (string, error) {
+	tmpl, err := raymond.Parse(src)
+	if err != nil {
+		return "", formatTemplateError(err)
+	}
+
+	out, err := tmpl.Exec(data)
+	if err != nil {
+		return "", formatTemplateError(err)
+	}
+	return out, nil
+}
+
+// templateLineRe extracts the line number Go's text/template and raymond
+// both report in their error strings, e.g. "template: output_iframe:3: ...".
+var templateLineRe = regexp.MustCompile(`:(\d+):`)
+
+func formatTemplateError(err error) error {
+	if m := templateLineRe.FindStringSubmatch(err.Error()); m != nil {
+		return fmt.Errorf("output_iframe: template error at line %s: %w", m[1], err)
+	}
+	return fmt.Errorf("output_iframe: template error: %w", err)
+}
+
+func templateJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// humanizeBytes renders n bytes as e.g. "4.2 MiB".
+func humanizeBytes(n float64) string {
+	const unit = 1024.0
+	if n < unit {
+		return fmt.Sprintf("%.0f B", n)
+	}
+	div, exp := unit, 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	suffixes := []string{"KiB", "MiB", "GiB", "TiB", "PiB"}
+	return fmt.Sprintf("%.1f %s", n/div, suffixes[exp])
+}
+
+// humanizeTime renders a time.Time, RFC3339 string, or unix timestamp as a
+// human-readable date.
+func humanizeTime(v any) (string, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(time.RFC1123), nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return "", fmt.Errorf("humanize_time: %w", err)
+		}
+		return parsed.Format(time.RFC1123), nil
+	case float64:
+		return time.Unix(int64(t), 0).UTC().Format(time.RFC1123), nil
+	default:
+		return "", fmt.Errorf("humanize_time: unsupported value %v (%T)", v, v)
+	}
+}
+
+// templateDefault returns v, or fallback if v is the zero value for its type
+// (nil, "", 0, false, or an empty slice/map).
+func templateDefault(fallback, v any) any {
+	if v == nil {
+		return fallback
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if rv.Len() == 0 {
+			return fallback
+		}
+	default:
+		if rv.IsZero() {
+			return fallback
+		}
+	}
+	return v
+}
+
+// templateSlice returns items[start:end], clamped to items' bounds, for
+// paging over a dataset from within a template.
+func templateSlice(items any, start, end int) (any, error) {
+	rv := reflect.ValueOf(items)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("slice: not a list: %T", items)
+	}
+	n := rv.Len()
+	if start < 0 {
+		start = 0
+	}
+	if end > n {
+		end = n
+	}
+	if end < 0 {
+		end = 0
+	}
+	if start > end {
+		start = end
+	}
+	return rv.Slice(start, end).Interface(), nil
+}
@@ -0,0 +1,121 @@
+package claudetool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderOutputTemplateGo(t *testing.T) {
+	data, _ := json.Marshal(map[string]any{"name": "World", "count": 3})
+	out, err := renderOutputTemplate(`<h1>Hello {{.name}}, you have {{.count}} items</h1>`, data, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "Hello World, you have 3 items") {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestRenderOutputTemplateGoAutoEscapes(t *testing.T) {
+	data, _ := json.Marshal(map[string]any{"name": `<script>alert(1)</script>`})
+	out, err := renderOutputTemplate(`<p>{{.name}}</p>`, data, "go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out, "<script>") {
+		t.Errorf("expected html/template to escape data, got %q", out)
+	}
+}
+
+func TestRenderOutputTemplateHandlebars(t *testing.T) {
+	data, _ := json.Marshal(map[string]any{"name": "World", "count": 3})
+	out, err := renderOutputTemplate(`<h1>Hello {{name}}, you have {{count}} items</h1>`, data, "handlebars")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "Hello World, you have 3 items") {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestRenderOutputTemplateHandlebarsEscapesHTML(t *testing.T) {
+	data, _ := json.Marshal(map[string]any{"name": `<script>alert(1)</script>`})
+	out, err := renderOutputTemplate(`<p>{{name}}</p>`, data, "handlebars")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out, "<script>") {
+		t.Errorf("expected handlebars' default {{...}} to HTML-escape data, got %q", out)
+	}
+	if !strings.Contains(out, "&lt;script&gt;") {
+		t.Errorf("expected an HTML-entity-escaped script tag, got %q", out)
+	}
+}
+
+func TestRenderOutputTemplateUnknownEngine(t *testing.T) {
+	_, err := renderOutputTemplate(`hi`, nil, "mustache")
+	if err == nil {
+		t.Fatal("expected an error for an unknown engine")
+	}
+}
+
+func TestRenderOutputTemplateParseErrorIncludesLine(t *testing.T) {
+	_, err := renderOutputTemplate("line one\nline two {{.Bad\n", nil, "go")
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if !strings.Contains(err.Error(), "line") {
+		t.Errorf("expected error to mention a line number, got %v", err)
+	}
+}
+
+func TestHumanizeBytes(t *testing.T) {
+	if got := humanizeBytes(500); got != "500 B" {
+		t.Errorf("got %q", got)
+	}
+	if got := humanizeBytes(1536); got != "1.5 KiB" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTemplateDefault(t *testing.T) {
+	if got := templateDefault("fallback", ""); got != "fallback" {
+		t.Errorf("expected fallback for empty string, got %v", got)
+	}
+	if got := templateDefault("fallback", "value"); got != "value" {
+		t.Errorf("expected value to pass through, got %v", got)
+	}
+}
+
+func TestTemplateSliceClampsBounds(t *testing.T) {
+	items := []any{1, 2, 3, 4, 5}
+	got, err := templateSlice(items, 2, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s, ok := got.([]any); !ok || len(s) != 3 {
+		t.Errorf("expected 3 items, got %v", got)
+	}
+}
+
+func TestOutputIframeRunWithTemplate(t *testing.T) {
+	input := map[string]any{
+		"template": `<h1>{{.title}}</h1>`,
+		"data":     map[string]any{"title": "Report"},
+	}
+	inputJSON, _ := json.Marshal(input)
+
+	result := outputIframeRun(context.Background(), inputJSON)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	display, ok := result.Display.(OutputIframeDisplay)
+	if !ok {
+		t.Fatalf("expected OutputIframeDisplay, got %T", result.Display)
+	}
+	if !strings.Contains(display.HTML, "Report") {
+		t.Errorf("expected rendered template in output, got %q", display.HTML)
+	}
+}
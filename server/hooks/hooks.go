@@ -0,0 +1,201 @@
+// Package hooks implements Shelley's hook subsystem: discovering and running
+// user-provided executables at fixed points in a conversation's lifecycle.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event identifies a point in the conversation lifecycle at which hooks run.
+//
+// EventConversationStart is the only event the server actually invokes today
+// (see server.RunStartupHook). The lifecycle has room to grow, but until a
+// call site for, say, a tool-call hook exists, that event doesn't belong
+// here: an Event nothing ever fires is just a speculative enum value with
+// parsed-but-inert fields attached to it.
+type Event string
+
+const (
+	EventConversationStart Event = "on-conversation-start"
+)
+
+// DefaultTimeout is used for an event when Manager.Timeout is zero.
+const DefaultTimeout = 5 * time.Second
+
+// Request is the JSON document written to a hook's stdin.
+type Request struct {
+	Event          Event  `json:"event"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	Cwd            string `json:"cwd,omitempty"`
+}
+
+// Response is the optional JSON document a hook may write to stdout.
+// Stdout that doesn't parse as JSON is treated as free-form output instead,
+// preserving the behavior of the original startup hook.
+type Response struct {
+	// AppendSystemPrompt is text the hook wants appended to the system
+	// prompt for the conversation being started. Run has no system prompt
+	// to append it to, so it's surfaced on HookResult.Response for
+	// RunStartupHook's caller to apply.
+	AppendSystemPrompt string `json:"append_system_prompt,omitempty"`
+
+	// SetEnv is applied immediately by Run via os.Setenv, so it takes
+	// effect for both later hooks in the same chain and the server
+	// process that invoked Run.
+	SetEnv map[string]string `json:"set_env,omitempty"`
+
+	// Block stops Run from invoking any hooks after this one.
+	Block bool `json:"block,omitempty"`
+}
+
+// HookResult is the outcome of running a single hook executable.
+type HookResult struct {
+	Path     string
+	Output   string
+	Response *Response
+	Error    error
+}
+
+// Manager discovers and runs hooks under ConfigDir.
+type Manager struct {
+	// ConfigDir is the directory containing one subdirectory per Event.
+	// NewManager sets it to ~/.config/shelley/hooks; if it is empty, Run
+	// finds no hooks.
+	ConfigDir string
+
+	// Timeout bounds each hook execution. Defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// NewManager returns a Manager rooted at ~/.config/shelley/hooks.
+// It returns a zero-value Manager if the home directory can't be determined,
+// which causes Run to find no hooks.
+func NewManager() *Manager {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return &Manager{}
+	}
+	return &Manager{ConfigDir: filepath.Join(home, ".config", "shelley", "hooks")}
+}
+
+// Run executes every hook registered for event, in lexicographic order of
+// filename, and returns one HookResult per hook. It returns nil without
+// running anything if hooks are disabled for event.
+//
+// Two parts of a hook's Response are applied as Run goes rather than left
+// for the caller to interpret: SetEnv is exported into the process
+// environment via os.Setenv (so a later hook in the same chain, or the
+// server itself, observes it), and Block stops Run from invoking any
+// hooks after the one that set it.
+func (m *Manager) Run(ctx context.Context, event Event, req Request) []HookResult {
+	if m.Disabled(event) {
+		return nil
+	}
+
+	paths := m.findHooks(event)
+	if len(paths) == 0 {
+		return nil
+	}
+
+	req.Event = event
+	body, err := json.Marshal(req)
+	if err != nil {
+		return []HookResult{{Error: fmt.Errorf("marshal hook request: %w", err)}}
+	}
+
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	results := make([]HookResult, 0, len(paths))
+	for _, path := range paths {
+		result := runHook(ctx, path, req.Cwd, body, timeout)
+		results = append(results, result)
+		if result.Response == nil {
+			continue
+		}
+		for k, v := range result.Response.SetEnv {
+			os.Setenv(k, v)
+		}
+		if result.Response.Block {
+			break
+		}
+	}
+	return results
+}
+
+func runHook(ctx context.Context, path, dir string, body []byte, timeout time.Duration) HookResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path)
+	if dir != "" {
+		cmd.Dir = dir
+	}
+	cmd.Stdin = bytes.NewReader(body)
+	output, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return HookResult{Path: path, Output: string(output), Error: fmt.Errorf("hook timed out after %v: %s", timeout, path)}
+	}
+	if err != nil {
+		return HookResult{Path: path, Output: string(output), Error: fmt.Errorf("hook failed: %s: %w", path, err)}
+	}
+
+	result := HookResult{Path: path, Output: string(output)}
+	var resp Response
+	if json.Unmarshal(bytes.TrimSpace(output), &resp) == nil {
+		result.Response = &resp
+	}
+	return result
+}
+
+// findHooks returns the executable files under ConfigDir/event, sorted by
+// filename so a "NN-name" prefix convention gives users control over order.
+func (m *Manager) findHooks(event Event) []string {
+	if m.ConfigDir == "" {
+		return nil
+	}
+	dir := filepath.Join(m.ConfigDir, string(event))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Mode()&0111 == 0 {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Disabled reports whether event is turned off by the SHELLEY_DISABLE_HOOKS
+// kill switch or its per-event form, e.g.
+// SHELLEY_DISABLE_HOOKS_ON_CONVERSATION_START. Callers that fall back to other
+// behavior when Run finds no hooks configured (e.g. a legacy hook location)
+// must check Disabled first: Run also returns no results when hooks are
+// disabled, and that must not be mistaken for "none configured".
+func (m *Manager) Disabled(event Event) bool {
+	if os.Getenv("SHELLEY_DISABLE_HOOKS") != "" {
+		return true
+	}
+	suffix := strings.ToUpper(strings.ReplaceAll(string(event), "-", "_"))
+	return os.Getenv("SHELLEY_DISABLE_HOOKS_"+suffix) != ""
+}
@@ -0,0 +1,157 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeHook(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write hook %s: %v", name, err)
+	}
+}
+
+func TestFindHooksOrdersLexicographically(t *testing.T) {
+	dir := t.TempDir()
+	eventDir := filepath.Join(dir, string(EventConversationStart))
+	if err := os.MkdirAll(eventDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHook(t, eventDir, "10-c", "#!/bin/sh\necho c\n")
+	writeHook(t, eventDir, "01-b", "#!/bin/sh\necho b\n")
+	writeHook(t, eventDir, "02-a", "#!/bin/sh\necho a\n")
+	if err := os.WriteFile(filepath.Join(eventDir, "00-not-executable"), []byte("ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{ConfigDir: dir}
+	paths := m.findHooks(EventConversationStart)
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 executable hooks, got %d: %v", len(paths), paths)
+	}
+	want := []string{"01-b", "02-a", "10-c"}
+	for i, name := range want {
+		if filepath.Base(paths[i]) != name {
+			t.Errorf("hook %d: got %s, want %s", i, filepath.Base(paths[i]), name)
+		}
+	}
+}
+
+func TestRunParsesJSONResponse(t *testing.T) {
+	dir := t.TempDir()
+	eventDir := filepath.Join(dir, string(EventConversationStart))
+	if err := os.MkdirAll(eventDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHook(t, eventDir, "01-hook", "#!/bin/sh\necho '{\"append_system_prompt\": \"hi\"}'\n")
+
+	m := &Manager{ConfigDir: dir, Timeout: time.Second}
+	results := m.Run(context.Background(), EventConversationStart, Request{Cwd: "/tmp"})
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Error != nil {
+		t.Fatalf("unexpected error: %v", results[0].Error)
+	}
+	if results[0].Response == nil || results[0].Response.AppendSystemPrompt != "hi" {
+		t.Fatalf("expected parsed JSON Response, got %+v", results[0].Response)
+	}
+}
+
+func TestRunAppliesSetEnv(t *testing.T) {
+	dir := t.TempDir()
+	eventDir := filepath.Join(dir, string(EventConversationStart))
+	if err := os.MkdirAll(eventDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHook(t, eventDir, "01-hook", "#!/bin/sh\necho '{\"set_env\": {\"SHELLEY_HOOKS_TEST_VAR\": \"fromhook\"}}'\n")
+	writeHook(t, eventDir, "02-hook", "#!/bin/sh\necho \"got=$SHELLEY_HOOKS_TEST_VAR\"\n")
+	t.Cleanup(func() { os.Unsetenv("SHELLEY_HOOKS_TEST_VAR") })
+
+	m := &Manager{ConfigDir: dir, Timeout: time.Second}
+	results := m.Run(context.Background(), EventConversationStart, Request{})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[1].Output != "got=fromhook\n" {
+		t.Errorf("expected the second hook to observe the env var set by the first, got %q", results[1].Output)
+	}
+	if got := os.Getenv("SHELLEY_HOOKS_TEST_VAR"); got != "fromhook" {
+		t.Errorf("expected SetEnv to also be applied to the server process, got %q", got)
+	}
+}
+
+func TestRunStopsAfterBlock(t *testing.T) {
+	dir := t.TempDir()
+	eventDir := filepath.Join(dir, string(EventConversationStart))
+	if err := os.MkdirAll(eventDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHook(t, eventDir, "01-hook", "#!/bin/sh\necho '{\"block\": true}'\n")
+	writeHook(t, eventDir, "02-hook", "#!/bin/sh\necho should-not-run\n")
+
+	m := &Manager{ConfigDir: dir, Timeout: time.Second}
+	results := m.Run(context.Background(), EventConversationStart, Request{})
+	if len(results) != 1 {
+		t.Fatalf("expected Run to stop after the blocking hook, got %d results: %+v", len(results), results)
+	}
+}
+
+func TestRunTreatsNonJSONStdoutAsFreeform(t *testing.T) {
+	dir := t.TempDir()
+	eventDir := filepath.Join(dir, string(EventConversationStart))
+	if err := os.MkdirAll(eventDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHook(t, eventDir, "01-hook", "#!/bin/sh\necho 'hello there'\n")
+
+	m := &Manager{ConfigDir: dir, Timeout: time.Second}
+	results := m.Run(context.Background(), EventConversationStart, Request{})
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Response != nil {
+		t.Errorf("expected no parsed Response for free-form stdout, got %+v", results[0].Response)
+	}
+	if results[0].Output != "hello there\n" {
+		t.Errorf("expected output %q, got %q", "hello there\n", results[0].Output)
+	}
+}
+
+func TestDisabledGlobalKillSwitch(t *testing.T) {
+	t.Setenv("SHELLEY_DISABLE_HOOKS", "1")
+	m := &Manager{}
+	if !m.Disabled(EventConversationStart) {
+		t.Error("expected Disabled to report true when SHELLEY_DISABLE_HOOKS is set")
+	}
+}
+
+func TestDisabledPerEventKillSwitch(t *testing.T) {
+	t.Setenv("SHELLEY_DISABLE_HOOKS_ON_CONVERSATION_START", "1")
+	m := &Manager{}
+	if !m.Disabled(EventConversationStart) {
+		t.Error("expected Disabled to report true for the matching per-event switch")
+	}
+	if m.Disabled(Event("on-conversation-end")) {
+		t.Error("expected a different event to be unaffected by the per-event switch")
+	}
+}
+
+func TestRunReturnsNilResultsWhenDisabled(t *testing.T) {
+	dir := t.TempDir()
+	eventDir := filepath.Join(dir, string(EventConversationStart))
+	if err := os.MkdirAll(eventDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeHook(t, eventDir, "01-hook", "#!/bin/sh\necho hi\n")
+
+	t.Setenv("SHELLEY_DISABLE_HOOKS", "1")
+	m := &Manager{ConfigDir: dir}
+	if results := m.Run(context.Background(), EventConversationStart, Request{}); results != nil {
+		t.Errorf("expected nil results when hooks are disabled, got %v", results)
+	}
+}
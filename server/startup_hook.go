@@ -6,44 +6,95 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
-)
 
-const (
-	startupHookFilename = "on-conversation-start"
-	startupHookTimeout  = 5 * time.Second
+	"shelley.exe.dev/server/hooks"
 )
 
+const startupHookTimeout = 5 * time.Second
+
 // StartupHookResult contains the result of running the startup hook.
 type StartupHookResult struct {
 	Output string
 	Error  error
+
+	// SystemPromptAddition is the concatenation, in hook-run order, of every
+	// hooks.Response.AppendSystemPrompt a hook returned. RunStartupHook has
+	// no system prompt of its own to append it to; the caller that builds
+	// the conversation's system prompt is expected to append this to it.
+	SystemPromptAddition string
 }
 
-// RunStartupHook runs the startup hook if it exists.
-// It looks for ~/.config/shelley/on-conversation-start
-// The hook runs with the working directory set to cwd.
-// Returns nil if no hook exists or if SHELLEY_DISABLE_STARTUP_HOOK is set.
+// RunStartupHook runs the on-conversation-start hooks if any exist.
+// It looks for executables under ~/.config/shelley/hooks/on-conversation-start/,
+// falling back to the legacy single file ~/.config/shelley/on-conversation-start
+// for configs that predate the general hook manager.
+// The hook(s) run with the working directory set to cwd.
+// Returns nil if no hook exists or if SHELLEY_DISABLE_STARTUP_HOOK or
+// SHELLEY_DISABLE_HOOKS is set.
 func RunStartupHook(ctx context.Context, cwd string) *StartupHookResult {
 	if os.Getenv("SHELLEY_DISABLE_STARTUP_HOOK") != "" {
 		return nil
 	}
 
-	hookPath := findStartupHook()
-	if hookPath == "" {
+	mgr := hooks.NewManager()
+	if mgr.Disabled(hooks.EventConversationStart) {
 		return nil
 	}
 
-	// Check if executable
+	results := mgr.Run(ctx, hooks.EventConversationStart, hooks.Request{Cwd: cwd})
+	if len(results) > 0 {
+		return mergeResults(results)
+	}
+
+	return runLegacyStartupHook(ctx, cwd)
+}
+
+// mergeResults collapses a []hooks.HookResult into the single-hook shape
+// StartupHookResult has always exposed: outputs are concatenated in order,
+// the first error wins, and any append_system_prompt responses are
+// concatenated in the same order into SystemPromptAddition.
+func mergeResults(results []hooks.HookResult) *StartupHookResult {
+	var output, systemPrompt strings.Builder
+	var firstErr error
+	for _, r := range results {
+		output.WriteString(r.Output)
+		if firstErr == nil && r.Error != nil {
+			firstErr = r.Error
+		}
+		if r.Response != nil && r.Response.AppendSystemPrompt != "" {
+			if systemPrompt.Len() > 0 {
+				systemPrompt.WriteString("\n")
+			}
+			systemPrompt.WriteString(r.Response.AppendSystemPrompt)
+		}
+	}
+	return &StartupHookResult{
+		Output:               output.String(),
+		Error:                firstErr,
+		SystemPromptAddition: systemPrompt.String(),
+	}
+}
+
+// runLegacyStartupHook supports the pre-hooks.Manager layout where a single
+// executable file lived directly at ~/.config/shelley/on-conversation-start,
+// rather than under a hooks/on-conversation-start/ directory.
+func runLegacyStartupHook(ctx context.Context, cwd string) *StartupHookResult {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	hookPath := filepath.Join(home, ".config", "shelley", "on-conversation-start")
+
 	info, err := os.Stat(hookPath)
 	if err != nil {
-		return &StartupHookResult{Error: fmt.Errorf("failed to stat hook: %w", err)}
+		return nil
 	}
 	if info.Mode()&0111 == 0 {
 		return &StartupHookResult{Error: fmt.Errorf("hook is not executable: %s", hookPath)}
 	}
 
-	// Run with timeout
 	ctx, cancel := context.WithTimeout(ctx, startupHookTimeout)
 	defer cancel()
 
@@ -68,19 +119,3 @@ func RunStartupHook(ctx context.Context, cwd string) *StartupHookResult {
 
 	return &StartupHookResult{Output: string(output)}
 }
-
-// findStartupHook returns the path to the startup hook if it exists.
-func findStartupHook() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return ""
-	}
-
-	// Check ~/.config/shelley/on-conversation-start
-	hookPath := filepath.Join(home, ".config", "shelley", startupHookFilename)
-	if _, err := os.Stat(hookPath); err == nil {
-		return hookPath
-	}
-
-	return ""
-}
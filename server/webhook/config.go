@@ -0,0 +1,140 @@
+// Package webhook lets Shelley act as a lightweight CI-style automation
+// agent: it listens for webhook deliveries from GitHub, Gitea/Gogs, or a
+// generic JSON sender, and starts a new conversation for each one it
+// accepts, per a set of configured rules.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the top-level webhook listener configuration: how to
+// authenticate deliveries, where to write job artifacts, and which Rule
+// handles each accepted event.
+type Config struct {
+	// Addr is the address the webhook HTTP server listens on, e.g. ":8090".
+	Addr string `json:"addr" yaml:"addr"`
+
+	// Auth selects how inbound requests are authenticated.
+	Auth AuthConfig `json:"auth" yaml:"auth"`
+
+	// ArtifactDir is where per-job log files are written.
+	ArtifactDir string `json:"artifact_dir" yaml:"artifact_dir"`
+
+	// MaxConcurrentJobs bounds the worker pool. Defaults to 4.
+	MaxConcurrentJobs int `json:"max_concurrent_jobs" yaml:"max_concurrent_jobs"`
+
+	// Rules maps event selectors to the conversation that should run for
+	// them, in priority order: the first matching rule wins.
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// AuthConfig configures how inbound webhook requests are authenticated.
+// Exactly one of BearerToken or HMACSecret should be set.
+type AuthConfig struct {
+	// BearerToken, if set, must match the request's "Authorization: Bearer
+	// <token>" header.
+	BearerToken string `json:"bearer_token" yaml:"bearer_token"`
+
+	// HMACSecret, if set, verifies an X-Hub-Signature-256 (GitHub) or
+	// X-Gitea-Signature (Gitea/Gogs) HMAC-SHA256 signature over the body.
+	HMACSecret string `json:"hmac_secret" yaml:"hmac_secret"`
+}
+
+// Rule maps an event selector to the conversation it should start. Selector
+// fields left empty match anything.
+type Rule struct {
+	// EventType selects deliveries by type, e.g. "push", "pull_request".
+	EventType string `json:"event_type" yaml:"event_type"`
+
+	// Repo selects deliveries by repository full name, e.g. "acme/widget".
+	Repo string `json:"repo" yaml:"repo"`
+
+	// Branch selects deliveries by branch: the pushed ref's branch, or a
+	// pull request's head ref.
+	Branch string `json:"branch" yaml:"branch"`
+
+	// PromptTemplate is a text/template rendered against the parsed Event
+	// to produce the conversation's starting prompt.
+	PromptTemplate string `json:"prompt_template" yaml:"prompt_template"`
+
+	// Cwd is the working directory the conversation starts in.
+	Cwd string `json:"cwd" yaml:"cwd"`
+
+	// ToolsAllowlist restricts which tools the conversation may use. Empty
+	// means the default allowlist.
+	ToolsAllowlist []string `json:"tools_allowlist" yaml:"tools_allowlist"`
+
+	// HookEnv is added to the environment hooks run with for this
+	// conversation.
+	HookEnv map[string]string `json:"hook_env" yaml:"hook_env"`
+}
+
+// Matches reports whether e should be handled by r.
+func (r Rule) Matches(e Event) bool {
+	if r.EventType != "" && r.EventType != e.Type {
+		return false
+	}
+	if r.Repo != "" && r.Repo != e.Repo {
+		return false
+	}
+	if r.Branch != "" && r.Branch != e.Branch {
+		return false
+	}
+	return true
+}
+
+// LoadConfig reads a Config from a JSON or YAML file, chosen by the file
+// extension (".json", or ".yaml"/".yml").
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: failed to read config %s: %w", path, err)
+	}
+
+	var cfg Config
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal(data, &cfg)
+	} else {
+		err = yaml.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("webhook: failed to parse config %s: %w", path, err)
+	}
+
+	if cfg.MaxConcurrentJobs <= 0 {
+		cfg.MaxConcurrentJobs = 4
+	}
+	if err := cfg.Auth.validate(); err != nil {
+		return nil, fmt.Errorf("webhook: config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// validate rejects an AuthConfig that doesn't set exactly one of
+// BearerToken or HMACSecret. An unset Auth block must never be treated as
+// "no auth required" for the webhook listener.
+func (a AuthConfig) validate() error {
+	if a.BearerToken == "" && a.HMACSecret == "" {
+		return fmt.Errorf("auth.bearer_token or auth.hmac_secret must be set")
+	}
+	if a.BearerToken != "" && a.HMACSecret != "" {
+		return fmt.Errorf("auth must set exactly one of bearer_token or hmac_secret")
+	}
+	return nil
+}
+
+// FirstMatch returns the first rule matching e, or ok=false if none do.
+func (c *Config) FirstMatch(e Event) (Rule, bool) {
+	for _, r := range c.Rules {
+		if r.Matches(e) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
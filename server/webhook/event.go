@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Event is Shelley's provider-agnostic view of a webhook delivery, extracted
+// from a GitHub, Gitea/Gogs, or generic JSON payload. It's also the value a
+// Rule's PromptTemplate is rendered against.
+type Event struct {
+	Type   string // e.g. "push", "pull_request"
+	Repo   string // full name, e.g. "acme/widget"
+	Branch string
+	SHA    string
+	Author string
+	Title  string
+	Body   string
+	Files  []string
+
+	// RawPayload is the original request body, for templates that need a
+	// field this package doesn't extract.
+	RawPayload json.RawMessage
+}
+
+// Format identifies which provider sent a delivery, used to pick a parser.
+type Format string
+
+const (
+	FormatGitHub  Format = "github"
+	FormatGitea   Format = "gitea"
+	FormatGeneric Format = "generic"
+)
+
+// ParseEvent extracts an Event from a raw webhook body in the given format.
+// eventType is the provider's event-type header (GitHub's X-GitHub-Event or
+// Gitea's X-Gitea-Event); it's ignored for FormatGeneric, which reads
+// "event_type" from the payload itself.
+func ParseEvent(format Format, eventType string, body []byte) (Event, error) {
+	switch format {
+	case FormatGitHub, FormatGitea:
+		return parseGitHubStyleEvent(eventType, body)
+	case FormatGeneric:
+		return parseGenericEvent(body)
+	default:
+		return Event{}, fmt.Errorf("webhook: unknown format %q", format)
+	}
+}
+
+// parseGitHubStyleEvent handles both GitHub and Gitea/Gogs payloads: Gitea's
+// webhook format is intentionally compatible with GitHub's for the fields
+// this function reads.
+func parseGitHubStyleEvent(eventType string, body []byte) (Event, error) {
+	var p struct {
+		Ref        string `json:"ref"`
+		After      string `json:"after"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+		HeadCommit struct {
+			ID      string `json:"id"`
+			Message string `json:"message"`
+			Author  struct {
+				Name string `json:"name"`
+			} `json:"author"`
+			Added    []string `json:"added"`
+			Removed  []string `json:"removed"`
+			Modified []string `json:"modified"`
+		} `json:"head_commit"`
+		PullRequest struct {
+			Title string `json:"title"`
+			Body  string `json:"body"`
+			Head  struct {
+				Ref string `json:"ref"`
+				SHA string `json:"sha"`
+			} `json:"head"`
+			User struct {
+				Login string `json:"login"`
+			} `json:"user"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		return Event{}, fmt.Errorf("webhook: failed to parse payload: %w", err)
+	}
+
+	e := Event{Type: eventType, Repo: p.Repository.FullName, RawPayload: body}
+	if eventType == "pull_request" {
+		e.Branch = p.PullRequest.Head.Ref
+		e.SHA = p.PullRequest.Head.SHA
+		e.Author = p.PullRequest.User.Login
+		e.Title = p.PullRequest.Title
+		e.Body = p.PullRequest.Body
+		return e, nil
+	}
+
+	// push, and anything else shaped like one.
+	e.Branch = branchFromRef(p.Ref)
+	e.SHA = p.HeadCommit.ID
+	if e.SHA == "" {
+		e.SHA = p.After
+	}
+	e.Author = p.HeadCommit.Author.Name
+	e.Title = p.HeadCommit.Message
+	e.Files = append(e.Files, p.HeadCommit.Added...)
+	e.Files = append(e.Files, p.HeadCommit.Removed...)
+	e.Files = append(e.Files, p.HeadCommit.Modified...)
+	return e, nil
+}
+
+func parseGenericEvent(body []byte) (Event, error) {
+	var p struct {
+		EventType string   `json:"event_type"`
+		Repo      string   `json:"repo"`
+		Branch    string   `json:"branch"`
+		SHA       string   `json:"sha"`
+		Author    string   `json:"author"`
+		Title     string   `json:"title"`
+		Body      string   `json:"body"`
+		Files     []string `json:"files"`
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		return Event{}, fmt.Errorf("webhook: failed to parse generic payload: %w", err)
+	}
+	return Event{
+		Type:       p.EventType,
+		Repo:       p.Repo,
+		Branch:     p.Branch,
+		SHA:        p.SHA,
+		Author:     p.Author,
+		Title:      p.Title,
+		Body:       p.Body,
+		Files:      p.Files,
+		RawPayload: body,
+	}, nil
+}
+
+func branchFromRef(ref string) string {
+	const prefix = "refs/heads/"
+	if strings.HasPrefix(ref, prefix) {
+		return ref[len(prefix):]
+	}
+	return ref
+}
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// JobState is the lifecycle state of a webhook-triggered job.
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+)
+
+const tailLines = 50
+
+// Job tracks one conversation started in response to a webhook delivery.
+type Job struct {
+	ID      string
+	Key     string // event_type/repo/sha; duplicate deliveries collapse onto it
+	Event   Event
+	Rule    Rule
+	LogPath string
+
+	QueuedAt   time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	mu    sync.Mutex
+	state JobState
+	err   error
+	tail  []string // last few lines of output, for the status endpoint
+}
+
+func (j *Job) State() JobState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.state
+}
+
+func (j *Job) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+func (j *Job) setState(s JobState) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state = s
+}
+
+// appendOutput records a line of job output, keeping only the most recent
+// tailLines for the status endpoint; full output still goes to LogPath.
+func (j *Job) appendOutput(line string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.tail = append(j.tail, line)
+	if over := len(j.tail) - tailLines; over > 0 {
+		j.tail = j.tail[over:]
+	}
+}
+
+func (j *Job) Tail() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := make([]string, len(j.tail))
+	copy(out, j.tail)
+	return out
+}
+
+// Registry tracks every job it has started, in flight or finished, collapsing
+// duplicate deliveries of the same event_type/repo/sha onto one Job.
+type Registry struct {
+	mu     sync.Mutex
+	jobs   map[string]*Job // by ID
+	byKey  map[string]*Job // in-flight jobs, by dedup key
+	nextID int
+}
+
+func NewRegistry() *Registry {
+	return &Registry{jobs: map[string]*Job{}, byKey: map[string]*Job{}}
+}
+
+// dedupKey is the event_type/repo/sha triple duplicate deliveries share.
+func dedupKey(e Event) string {
+	return fmt.Sprintf("%s/%s/%s", e.Type, e.Repo, e.SHA)
+}
+
+// StartOrJoin returns the Job for e's dedup key, creating a queued one if
+// none is in flight. existed reports whether a matching job was already in
+// flight, in which case the caller should not start a new conversation.
+func (r *Registry) StartOrJoin(e Event, rule Rule, logPath string) (job *Job, existed bool) {
+	key := dedupKey(e)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.byKey[key]; ok {
+		return existing, true
+	}
+
+	r.nextID++
+	job = &Job{
+		ID:       fmt.Sprintf("job-%d", r.nextID),
+		Key:      key,
+		Event:    e,
+		Rule:     rule,
+		LogPath:  logPath,
+		QueuedAt: time.Now(),
+		state:    JobQueued,
+	}
+	r.jobs[job.ID] = job
+	r.byKey[key] = job
+	return job, false
+}
+
+// Finish marks job as done and removes it from the in-flight dedup index,
+// so a later delivery with the same key starts a fresh job.
+func (r *Registry) Finish(job *Job, err error) {
+	job.mu.Lock()
+	job.FinishedAt = time.Now()
+	job.err = err
+	if err != nil {
+		job.state = JobFailed
+	} else {
+		job.state = JobSucceeded
+	}
+	job.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.byKey[job.Key] == job {
+		delete(r.byKey, job.Key)
+	}
+}
+
+func (r *Registry) Get(id string) (*Job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[id]
+	return j, ok
+}
+
+func (r *Registry) List() []*Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*Job, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		out = append(out, j)
+	}
+	return out
+}
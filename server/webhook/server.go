@@ -0,0 +1,255 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ConversationRunner starts a new Shelley conversation for an accepted
+// webhook job. Output the conversation produces should be written to w,
+// which the Server uses to populate the job's log file and status tail.
+type ConversationRunner interface {
+	RunConversation(ctx context.Context, req ConversationRequest, w io.Writer) error
+}
+
+// Server receives webhook deliveries and turns accepted ones into Shelley
+// conversations, per Config.Rules. Deliveries that don't match a rule are
+// acknowledged but otherwise ignored.
+type Server struct {
+	cfg      *Config
+	format   Format
+	runner   ConversationRunner
+	registry *Registry
+	jobs     chan *Job
+}
+
+// NewServer returns a Server that dispatches accepted deliveries, parsed as
+// format, to runner. It starts cfg.MaxConcurrentJobs worker goroutines that
+// run until the process exits; there's no Close, mirroring the rest of this
+// package's fire-and-forget job model.
+func NewServer(cfg *Config, format Format, runner ConversationRunner) *Server {
+	s := &Server{
+		cfg:      cfg,
+		format:   format,
+		runner:   runner,
+		registry: NewRegistry(),
+		jobs:     make(chan *Job, 64),
+	}
+	for i := 0; i < cfg.MaxConcurrentJobs; i++ {
+		go s.worker()
+	}
+	return s
+}
+
+// Handler returns the http.Handler serving webhook deliveries and job status.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", s.handleWebhook)
+	mux.HandleFunc("/jobs", s.handleJobsList)
+	mux.HandleFunc("/jobs/", s.handleJobStatus)
+	return mux
+}
+
+func (s *Server) worker() {
+	for job := range s.jobs {
+		s.runJob(job)
+	}
+}
+
+func (s *Server) runJob(job *Job) {
+	job.setState(JobRunning)
+	job.StartedAt = time.Now()
+
+	req, err := renderRule(job.Rule, job.Event)
+	if err != nil {
+		s.registry.Finish(job, err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(job.LogPath), 0o755); err != nil {
+		s.registry.Finish(job, fmt.Errorf("webhook: failed to create artifact dir: %w", err))
+		return
+	}
+	logFile, err := os.Create(job.LogPath)
+	if err != nil {
+		s.registry.Finish(job, fmt.Errorf("webhook: failed to create log file: %w", err))
+		return
+	}
+	defer logFile.Close()
+
+	w := io.MultiWriter(logFile, &tailWriter{job: job})
+	err = s.runner.RunConversation(context.Background(), req, w)
+	s.registry.Finish(job, err)
+}
+
+// tailWriter splits lines written to it into Job.appendOutput, so the
+// status endpoint can show recent output without re-reading the log file.
+type tailWriter struct {
+	job *Job
+	buf bytes.Buffer
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.buf.Write(p)
+	for {
+		line, err := t.buf.ReadString('\n')
+		if err != nil {
+			// Incomplete line: put it back and wait for more.
+			t.buf.Reset()
+			t.buf.WriteString(line)
+			break
+		}
+		t.job.appendOutput(strings.TrimSuffix(line, "\n"))
+	}
+	return len(p), nil
+}
+
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.cfg.Auth.verify(r, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	eventType := r.Header.Get("X-GitHub-Event")
+	if eventType == "" {
+		eventType = r.Header.Get("X-Gitea-Event")
+	}
+
+	event, err := ParseEvent(s.format, eventType, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule, ok := s.cfg.FirstMatch(event)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	logName := strings.ReplaceAll(dedupKey(event), "/", "_") + "-" + time.Now().UTC().Format("20060102T150405.000000000") + ".log"
+	logPath := filepath.Join(s.cfg.ArtifactDir, logName)
+	job, existed := s.registry.StartOrJoin(event, rule, logPath)
+	if !existed {
+		select {
+		case s.jobs <- job:
+		default:
+			log.Printf("webhook: job queue full, dropping delivery for %s", job.Key)
+			s.registry.Finish(job, fmt.Errorf("webhook: job queue full"))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"job_id": job.ID, "state": string(job.State())})
+}
+
+func (s *Server) handleJobsList(w http.ResponseWriter, r *http.Request) {
+	jobs := s.registry.List()
+	summaries := make([]jobSummary, len(jobs))
+	for i, j := range jobs {
+		summaries[i] = summarize(j)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(summaries)
+}
+
+func (s *Server) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+	job, ok := s.registry.Get(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(summarize(job))
+}
+
+// jobSummary is the JSON shape of the /jobs and /jobs/{id} endpoints.
+type jobSummary struct {
+	ID         string    `json:"id"`
+	State      JobState  `json:"state"`
+	EventType  string    `json:"event_type"`
+	Repo       string    `json:"repo"`
+	SHA        string    `json:"sha"`
+	Error      string    `json:"error,omitempty"`
+	QueuedAt   time.Time `json:"queued_at"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+	Tail       []string  `json:"tail,omitempty"`
+}
+
+func summarize(j *Job) jobSummary {
+	sum := jobSummary{
+		ID:         j.ID,
+		State:      j.State(),
+		EventType:  j.Event.Type,
+		Repo:       j.Event.Repo,
+		SHA:        j.Event.SHA,
+		QueuedAt:   j.QueuedAt,
+		StartedAt:  j.StartedAt,
+		FinishedAt: j.FinishedAt,
+		Tail:       j.Tail(),
+	}
+	if err := j.Err(); err != nil {
+		sum.Error = err.Error()
+	}
+	return sum
+}
+
+// verify checks an inbound request against a, returning an error describing
+// the first check that failed. An AuthConfig with neither field set is
+// refused rather than treated as "no auth required": an unconfigured or
+// misconfigured Auth block must never make every request accepted.
+func (a AuthConfig) verify(r *http.Request, body []byte) error {
+	if err := a.validate(); err != nil {
+		return fmt.Errorf("webhook: refusing request: %w", err)
+	}
+
+	switch {
+	case a.BearerToken != "":
+		header := r.Header.Get("Authorization")
+		got := strings.TrimPrefix(header, "Bearer ")
+		if got == header || subtle.ConstantTimeCompare([]byte(got), []byte(a.BearerToken)) != 1 {
+			return fmt.Errorf("webhook: invalid bearer token")
+		}
+	default: // a.HMACSecret != ""
+		sig := r.Header.Get("X-Hub-Signature-256")
+		if sig == "" {
+			sig = r.Header.Get("X-Gitea-Signature")
+		}
+		if !validHMAC(a.HMACSecret, sig, body) {
+			return fmt.Errorf("webhook: invalid signature")
+		}
+	}
+	return nil
+}
+
+func validHMAC(secret, sigHeader string, body []byte) bool {
+	sigHeader = strings.TrimPrefix(sigHeader, "sha256=")
+	want, err := hex.DecodeString(sigHeader)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
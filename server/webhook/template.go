@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// ConversationRequest is what a Rule resolves to once its PromptTemplate has
+// been rendered against an Event.
+type ConversationRequest struct {
+	Prompt         string
+	Cwd            string
+	ToolsAllowlist []string
+	HookEnv        map[string]string
+}
+
+// renderRule renders r.PromptTemplate against e to produce a
+// ConversationRequest. The template sees e's exported fields directly, e.g.
+// "{{.Author}} pushed {{.SHA}}: {{.Title}}".
+func renderRule(r Rule, e Event) (ConversationRequest, error) {
+	tmpl, err := template.New("prompt").Parse(r.PromptTemplate)
+	if err != nil {
+		return ConversationRequest{}, fmt.Errorf("webhook: failed to parse prompt_template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, e); err != nil {
+		return ConversationRequest{}, fmt.Errorf("webhook: failed to render prompt_template: %w", err)
+	}
+
+	return ConversationRequest{
+		Prompt:         buf.String(),
+		Cwd:            r.Cwd,
+		ToolsAllowlist: r.ToolsAllowlist,
+		HookEnv:        r.HookEnv,
+	}, nil
+}
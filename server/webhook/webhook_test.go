@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseEventGitHubPush(t *testing.T) {
+	body := []byte(`{
+		"ref": "refs/heads/main",
+		"repository": {"full_name": "acme/widget"},
+		"head_commit": {"id": "abc123", "message": "fix bug", "author": {"name": "jo"}, "added": ["a.go"]}
+	}`)
+	e, err := ParseEvent(FormatGitHub, "push", body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Type != "push" || e.Repo != "acme/widget" || e.Branch != "main" || e.SHA != "abc123" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if len(e.Files) != 1 || e.Files[0] != "a.go" {
+		t.Errorf("expected files [a.go], got %v", e.Files)
+	}
+}
+
+func TestParseEventGitHubPullRequest(t *testing.T) {
+	body := []byte(`{
+		"repository": {"full_name": "acme/widget"},
+		"pull_request": {
+			"title": "Add feature", "body": "details",
+			"head": {"ref": "feature-x", "sha": "def456"},
+			"user": {"login": "jo"}
+		}
+	}`)
+	e, err := ParseEvent(FormatGitHub, "pull_request", body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Branch != "feature-x" || e.SHA != "def456" || e.Title != "Add feature" || e.Author != "jo" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
+
+func TestParseEventGeneric(t *testing.T) {
+	body := []byte(`{"event_type": "deploy", "repo": "acme/widget", "sha": "xyz", "files": ["b.go"]}`)
+	e, err := ParseEvent(FormatGeneric, "", body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Type != "deploy" || e.Repo != "acme/widget" || e.SHA != "xyz" || len(e.Files) != 1 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	r := Rule{EventType: "push", Repo: "acme/widget"}
+	match := Event{Type: "push", Repo: "acme/widget", Branch: "main"}
+	if !r.Matches(match) {
+		t.Error("expected rule to match")
+	}
+	if r.Matches(Event{Type: "push", Repo: "other/repo"}) {
+		t.Error("expected rule not to match a different repo")
+	}
+}
+
+func TestRegistryCollapsesDuplicateDeliveries(t *testing.T) {
+	reg := NewRegistry()
+	e := Event{Type: "push", Repo: "acme/widget", SHA: "abc"}
+
+	job1, existed1 := reg.StartOrJoin(e, Rule{}, "/tmp/job.log")
+	if existed1 {
+		t.Fatal("expected first delivery to start a new job")
+	}
+	job2, existed2 := reg.StartOrJoin(e, Rule{}, "/tmp/job.log")
+	if !existed2 || job2 != job1 {
+		t.Fatal("expected duplicate delivery to join the in-flight job")
+	}
+
+	reg.Finish(job1, nil)
+	job3, existed3 := reg.StartOrJoin(e, Rule{}, "/tmp/job.log")
+	if existed3 || job3 == job1 {
+		t.Fatal("expected a new delivery after Finish to start a fresh job")
+	}
+}
+
+func TestAuthConfigVerifyBearerToken(t *testing.T) {
+	auth := AuthConfig{BearerToken: "secret"}
+	req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	if err := auth.verify(req, nil); err != nil {
+		t.Errorf("expected valid token to pass: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer wrong")
+	if err := auth.verify(req, nil); err == nil {
+		t.Error("expected invalid token to fail")
+	}
+}
+
+func TestAuthConfigVerifyHMAC(t *testing.T) {
+	auth := AuthConfig{HMACSecret: "secret"}
+	body := []byte(`{"hello":"world"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+	req.Header.Set("X-Hub-Signature-256", sig)
+	if err := auth.verify(req, body); err != nil {
+		t.Errorf("expected valid signature to pass: %v", err)
+	}
+
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString([]byte("wrong")))
+	if err := auth.verify(req, body); err == nil {
+		t.Error("expected invalid signature to fail")
+	}
+}
+
+func TestAuthConfigVerifyRefusesUnconfiguredAuth(t *testing.T) {
+	auth := AuthConfig{}
+	req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+	if err := auth.verify(req, nil); err == nil {
+		t.Fatal("expected an unconfigured Auth block to refuse every request, not accept it")
+	}
+}
+
+func TestAuthConfigVerifyRefusesBothSet(t *testing.T) {
+	auth := AuthConfig{BearerToken: "secret", HMACSecret: "secret"}
+	req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	if err := auth.verify(req, nil); err == nil {
+		t.Fatal("expected setting both bearer_token and hmac_secret to be rejected")
+	}
+}
+
+func TestLoadConfigRejectsMissingAuth(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"addr": ":8090", "rules": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected LoadConfig to reject a config with no auth configured")
+	}
+}
+
+func TestLoadConfigRejectsBothAuthFields(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	cfg := `{"addr": ":8090", "auth": {"bearer_token": "a", "hmac_secret": "b"}, "rules": []}`
+	if err := os.WriteFile(path, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected LoadConfig to reject a config with both auth fields set")
+	}
+}